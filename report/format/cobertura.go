@@ -0,0 +1,116 @@
+// Package format renders a report.Report into formats consumed by other tools:
+// Cobertura XML (Jenkins, GitLab, SonarQube) and Code Climate JSON.
+package format
+
+import (
+	"encoding/xml"
+	"io"
+	"path"
+	"sort"
+
+	"github.com/preethi29/goverreport/report"
+)
+
+const coberturaHeader = `<?xml version="1.0" ?>
+<!DOCTYPE coverage SYSTEM "http://cobertura.sourceforge.net/xml/coverage-04.dtd">
+`
+
+type coberturaCoverage struct {
+	XMLName    xml.Name           `xml:"coverage"`
+	LineRate   float64            `xml:"line-rate,attr"`
+	BranchRate float64            `xml:"branch-rate,attr"`
+	Version    string             `xml:"version,attr"`
+	Timestamp  int64              `xml:"timestamp,attr"`
+	Packages   []coberturaPackage `xml:"packages>package"`
+}
+
+type coberturaPackage struct {
+	Name       string           `xml:"name,attr"`
+	LineRate   float64          `xml:"line-rate,attr"`
+	BranchRate float64          `xml:"branch-rate,attr"`
+	Classes    []coberturaClass `xml:"classes>class"`
+}
+
+type coberturaClass struct {
+	Name       string          `xml:"name,attr"`
+	Filename   string          `xml:"filename,attr"`
+	LineRate   float64         `xml:"line-rate,attr"`
+	BranchRate float64         `xml:"branch-rate,attr"`
+	Lines      []coberturaLine `xml:"lines>line"`
+}
+
+type coberturaLine struct {
+	Number int `xml:"number,attr"`
+	Hits   int `xml:"hits,attr"`
+}
+
+// WriteCobertura renders r as a Cobertura XML coverage report to w.
+func WriteCobertura(w io.Writer, r report.Report) error {
+	detailsByName := make(map[string]report.FileDetail, len(r.Details))
+	for _, detail := range r.Details {
+		detailsByName[detail.Name] = detail
+	}
+	classesByPackage := make(map[string][]coberturaClass)
+	for _, file := range r.Files {
+		classesByPackage[path.Dir(file.Name)] = append(classesByPackage[path.Dir(file.Name)], coberturaClass{
+			Name:       path.Base(file.Name),
+			Filename:   file.Name,
+			LineRate:   file.StmtCoverage / 100,
+			BranchRate: file.BlockCoverage / 100,
+			Lines:      coberturaLines(detailsByName[file.Name]),
+		})
+	}
+	packageSummaryByName := make(map[string]report.Summary, len(r.Packages))
+	for _, pkg := range r.Packages {
+		packageSummaryByName[pkg.Name] = pkg
+	}
+	packageNames := make([]string, 0, len(classesByPackage))
+	for name := range classesByPackage {
+		packageNames = append(packageNames, name)
+	}
+	sort.Strings(packageNames)
+	packages := make([]coberturaPackage, 0, len(packageNames))
+	for _, name := range packageNames {
+		classes := classesByPackage[name]
+		sort.Slice(classes, func(i, j int) bool { return classes[i].Name < classes[j].Name })
+		packages = append(packages, coberturaPackage{
+			Name:       name,
+			LineRate:   packageSummaryByName[name].StmtCoverage / 100,
+			BranchRate: packageSummaryByName[name].BlockCoverage / 100,
+			Classes:    classes,
+		})
+	}
+	coverage := coberturaCoverage{
+		LineRate:   r.Total.StmtCoverage / 100,
+		BranchRate: r.Total.BlockCoverage / 100,
+		Version:    "1.0",
+		Packages:   packages,
+	}
+	if _, err := io.WriteString(w, coberturaHeader); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(coverage)
+}
+
+// Turns a FileDetail's blocks into one Cobertura <line> per source line, attributing
+// each line the sum of the hit counts of every block that covers it.
+func coberturaLines(detail report.FileDetail) []coberturaLine {
+	hitsByLine := make(map[int]int)
+	var lineNumbers []int
+	for _, block := range detail.Blocks {
+		for line := block.StartLine; line <= block.EndLine; line++ {
+			if _, seen := hitsByLine[line]; !seen {
+				lineNumbers = append(lineNumbers, line)
+			}
+			hitsByLine[line] += block.Count
+		}
+	}
+	sort.Ints(lineNumbers)
+	lines := make([]coberturaLine, 0, len(lineNumbers))
+	for _, line := range lineNumbers {
+		lines = append(lines, coberturaLine{Number: line, Hits: hitsByLine[line]})
+	}
+	return lines
+}
@@ -0,0 +1,69 @@
+package format
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/preethi29/goverreport/report"
+)
+
+type codeClimateFile struct {
+	Lines        codeClimateLines `json:"lines"`
+	SourceDigest string           `json:"source_digest"`
+}
+
+type codeClimateLines struct {
+	Total   int `json:"total"`
+	Covered int `json:"covered"`
+}
+
+// WriteCodeClimate renders r as a Code Climate coverage JSON report to w: one entry
+// per file, keyed by file name, giving its total/covered line counts and an md5 digest
+// of its source. file.Name is root-trimmed by GenerateReport, so root is re-joined onto
+// it here to find the source on disk; pass the same root given to GenerateReport.
+func WriteCodeClimate(w io.Writer, r report.Report, root string) error {
+	detailsByName := make(map[string]report.FileDetail, len(r.Details))
+	for _, detail := range r.Details {
+		detailsByName[detail.Name] = detail
+	}
+	files := make(map[string]codeClimateFile, len(r.Files))
+	for _, file := range r.Files {
+		total, covered := lineCoverage(detailsByName[file.Name])
+		files[file.Name] = codeClimateFile{
+			Lines:        codeClimateLines{Total: total, Covered: covered},
+			SourceDigest: sourceDigest(filepath.Join(root, file.Name)),
+		}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(files)
+}
+
+// Derives a file's total/covered *source line* counts from its blocks' line ranges
+// (the same data coberturaLines renders per-line), rather than its statement counts:
+// Code Climate's "lines" field means source lines, and a single statement can span
+// several lines (or several statements can share one), so file.Stmts is only an
+// approximation of it.
+func lineCoverage(detail report.FileDetail) (total, covered int) {
+	for _, line := range coberturaLines(detail) {
+		total++
+		if line.Hits > 0 {
+			covered++
+		}
+	}
+	return total, covered
+}
+
+// Returns the md5 digest of a file's source, or "" if it can't be read.
+func sourceDigest(fileName string) string {
+	source, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return ""
+	}
+	sum := md5.Sum(source)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,111 @@
+package format
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/preethi29/goverreport/report"
+	"golang.org/x/tools/cover"
+)
+
+func testReport() report.Report {
+	return report.Report{
+		Total:    report.Summary{StmtCoverage: 75, BlockCoverage: 50},
+		Files:    []report.Summary{{Name: "pkg/a.go", Stmts: 4, MissingStmts: 1, StmtCoverage: 75, BlockCoverage: 50}},
+		Packages: []report.Summary{{Name: "pkg", StmtCoverage: 75, BlockCoverage: 50}},
+		Details: []report.FileDetail{{
+			Name: "pkg/a.go",
+			Blocks: []cover.ProfileBlock{
+				// 3 statements packed onto a single covered line...
+				{StartLine: 1, StartCol: 1, EndLine: 1, EndCol: 20, NumStmt: 3, Count: 1},
+				// ...and 1 statement on its own, uncovered, line.
+				{StartLine: 2, StartCol: 1, EndLine: 2, EndCol: 10, NumStmt: 1, Count: 0},
+			},
+		}},
+	}
+}
+
+func TestWriteCoberturaPopulatesBranchRate(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCobertura(&buf, testReport()); err != nil {
+		t.Fatalf("WriteCobertura returned error: %s", err)
+	}
+
+	var doc struct {
+		BranchRate float64 `xml:"branch-rate,attr"`
+		Packages   []struct {
+			BranchRate float64 `xml:"branch-rate,attr"`
+			Classes    []struct {
+				BranchRate float64 `xml:"branch-rate,attr"`
+			} `xml:"classes>class"`
+		} `xml:"packages>package"`
+	}
+	// Skip the literal DOCTYPE line before unmarshalling the XML document.
+	xmlStart := bytes.Index(buf.Bytes(), []byte("<coverage"))
+	if err := xml.Unmarshal(buf.Bytes()[xmlStart:], &doc); err != nil {
+		t.Fatalf("could not parse generated Cobertura XML: %s", err)
+	}
+
+	if doc.BranchRate != 0.5 {
+		t.Errorf("top-level branch-rate = %v, want 0.5", doc.BranchRate)
+	}
+	if len(doc.Packages) != 1 || doc.Packages[0].BranchRate != 0.5 {
+		t.Fatalf("package branch-rate = %+v, want 0.5", doc.Packages)
+	}
+	if len(doc.Packages[0].Classes) != 1 || doc.Packages[0].Classes[0].BranchRate != 0.5 {
+		t.Errorf("class branch-rate = %+v, want 0.5", doc.Packages[0].Classes)
+	}
+}
+
+func TestWriteCodeClimateResolvesDigestAgainstRoot(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	source := []byte("package pkg\n")
+	if err := os.WriteFile(filepath.Join(dir, "pkg", "a.go"), source, 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := md5.Sum(source)
+
+	var buf bytes.Buffer
+	if err := WriteCodeClimate(&buf, testReport(), dir); err != nil {
+		t.Fatalf("WriteCodeClimate returned error: %s", err)
+	}
+
+	if got := hex.EncodeToString(want[:]); !strings.Contains(buf.String(), got) {
+		t.Errorf("output %s does not contain expected source_digest %s", buf.String(), got)
+	}
+}
+
+func TestWriteCodeClimateLinesCountSourceLinesNotStatements(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCodeClimate(&buf, testReport(), ""); err != nil {
+		t.Fatalf("WriteCodeClimate returned error: %s", err)
+	}
+
+	var files map[string]struct {
+		Lines struct {
+			Total   int `json:"total"`
+			Covered int `json:"covered"`
+		} `json:"lines"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &files); err != nil {
+		t.Fatalf("could not parse generated Code Climate JSON: %s", err)
+	}
+
+	// testReport's pkg/a.go has 4 statements (Stmts: 4) spread over only 2 source
+	// lines, one of them covered: lines.total/covered must reflect line counts, not
+	// the statement counts.
+	got := files["pkg/a.go"].Lines
+	if got.Total != 2 || got.Covered != 1 {
+		t.Errorf("lines = %+v, want {Total:2 Covered:1}", got)
+	}
+}
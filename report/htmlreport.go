@@ -0,0 +1,190 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// WriteHTML renders r into dir: an index.html listing r.Files (in the order they're
+// already sorted in), and one <file>.html per profile with its source annotated from
+// profiles' ProfileBlocks, the same way `go tool cover -html` does. Source files are
+// located on disk by stripping root from each profile's file name (the same trim
+// GenerateReport applies to build Report.Files.Name) and reading what's left relative
+// to the current directory, so the result doesn't depend on GOPATH/build.Import being
+// able to resolve the covered packages.
+func WriteHTML(dir string, r Report, profiles []*cover.Profile, root string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create report directory '%s': %s", dir, err)
+	}
+	pages := make([]htmlIndexEntry, 0, len(r.Files))
+	for _, file := range r.Files {
+		pages = append(pages, htmlIndexEntry{Summary: file, Page: htmlPageName(file.Name)})
+	}
+	if err := writeHTMLIndex(filepath.Join(dir, "index.html"), r, pages); err != nil {
+		return err
+	}
+	for _, profile := range profiles {
+		fileName := trimRoot(profile.FileName, root)
+		if err := writeHTMLFilePage(filepath.Join(dir, htmlPageName(fileName)), fileName, profile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Turns a (root-trimmed) file name into a report HTML file name that's safe to put
+// next to index.html, e.g. "report/html.go" -> "report-html.go.html".
+func htmlPageName(fileName string) string {
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(fileName) + ".html"
+}
+
+type htmlIndexEntry struct {
+	Summary
+	Page string
+}
+
+var htmlIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Coverage report</title></head>
+<body>
+<h1>Coverage report</h1>
+<p>Total: {{printf "%.1f" .Report.Total.StmtCoverage}}% statements, {{printf "%.1f" .Report.Total.BlockCoverage}}% blocks</p>
+<table border="1" cellpadding="4" cellspacing="0">
+<tr><th>File</th><th>Stmt coverage</th><th>Block coverage</th></tr>
+{{range .Pages}}<tr><td><a href="{{.Page}}">{{.Name}}</a></td><td>{{printf "%.1f" .StmtCoverage}}%</td><td>{{printf "%.1f" .BlockCoverage}}%</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func writeHTMLIndex(path string, r Report, pages []htmlIndexEntry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return htmlIndexTemplate.Execute(f, struct {
+		Report Report
+		Pages  []htmlIndexEntry
+	}{r, pages})
+}
+
+func writeHTMLFilePage(path, fileName string, profile *cover.Profile) error {
+	src, err := os.ReadFile(fileName)
+	if err != nil {
+		return fmt.Errorf("can't read source for '%s': %s", fileName, err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "<!DOCTYPE html>\n<html>\n<head><meta charset=\"utf-8\"><title>%s</title>\n%s</head>\n<body>\n<pre>\n", fileName, htmlStyle); err != nil {
+		return err
+	}
+	if err := genHTML(f, src, findBoundaries(src, profile)); err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(f, "</pre>\n</body>\n</html>\n")
+	return err
+}
+
+const htmlStyle = `<style>
+	.cov-miss { color: rgb(192, 0, 0); }
+	.cov-hit { color: rgb(0, 128, 0); }
+</style>
+`
+
+// A boundary marks where a shaded <span> should open or close in the annotated source,
+// at the byte offset it was found, mirroring go tool cover's own html.go boundary logic.
+type boundary struct {
+	offset int
+	start  bool
+	count  int
+}
+
+// Walks src counting lines/columns and, for every block in profile, emits a start
+// boundary at its StartLine/StartCol and an end boundary at its EndLine/EndCol.
+func findBoundaries(src []byte, profile *cover.Profile) []boundary {
+	boundaries := make([]boundary, 0, len(profile.Blocks)*2)
+	for _, block := range profile.Blocks {
+		boundaries = append(boundaries,
+			boundary{offset: offsetFor(src, block.StartLine, block.StartCol), start: true, count: block.Count},
+			boundary{offset: offsetFor(src, block.EndLine, block.EndCol), start: false})
+	}
+	sort.Slice(boundaries, func(i, j int) bool {
+		if boundaries[i].offset != boundaries[j].offset {
+			return boundaries[i].offset < boundaries[j].offset
+		}
+		// Close spans before opening new ones at the same offset.
+		return !boundaries[i].start && boundaries[j].start
+	})
+	return boundaries
+}
+
+// Converts a 1-indexed (line, col) position into a byte offset into src.
+func offsetFor(src []byte, line, col int) int {
+	currentLine := 1
+	for offset, b := range src {
+		if currentLine == line {
+			return offset + col - 1
+		}
+		if b == '\n' {
+			currentLine++
+		}
+	}
+	return len(src)
+}
+
+// Writes src to w with each boundary's span shaded green (hit) or red (miss), escaping
+// HTML special characters as it goes; lines outside of any block are left unshaded.
+func genHTML(w io.Writer, src []byte, boundaries []boundary) error {
+	dst := bufio.NewWriter(w)
+	for i := range src {
+		for len(boundaries) > 0 && boundaries[0].offset <= i {
+			b := boundaries[0]
+			if b.start {
+				class := "cov-miss"
+				if b.count > 0 {
+					class = "cov-hit"
+				}
+				fmt.Fprintf(dst, `<span class="%s" title="%d">`, class, b.count)
+			} else {
+				dst.WriteString("</span>")
+			}
+			boundaries = boundaries[1:]
+		}
+		switch b := src[i]; b {
+		case '>':
+			dst.WriteString("&gt;")
+		case '<':
+			dst.WriteString("&lt;")
+		case '&':
+			dst.WriteString("&amp;")
+		default:
+			dst.WriteByte(b)
+		}
+	}
+	// A boundary exactly at EOF (e.g. a block ending at the last byte of src) never hits
+	// the `boundaries[0].offset <= i` check above, since i never reaches len(src).
+	for _, b := range boundaries {
+		if b.start {
+			class := "cov-miss"
+			if b.count > 0 {
+				class = "cov-hit"
+			}
+			fmt.Fprintf(dst, `<span class="%s" title="%d">`, class, b.count)
+		} else {
+			dst.WriteString("</span>")
+		}
+	}
+	return dst.Flush()
+}
@@ -0,0 +1,71 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func spanCounts(html string) (opens, closes int) {
+	return strings.Count(html, "<span"), strings.Count(html, "</span>")
+}
+
+func TestGenHTMLMarksHitAndMissBlocks(t *testing.T) {
+	src := []byte("package p\n\nfunc F() {\n\thit()\n\tmiss()\n}\n")
+	profile := &cover.Profile{Blocks: []cover.ProfileBlock{
+		{StartLine: 4, StartCol: 2, EndLine: 4, EndCol: 7, NumStmt: 1, Count: 1},
+		{StartLine: 5, StartCol: 2, EndLine: 5, EndCol: 8, NumStmt: 1, Count: 0},
+	}}
+
+	var buf bytes.Buffer
+	if err := genHTML(&buf, src, findBoundaries(src, profile)); err != nil {
+		t.Fatalf("genHTML returned error: %s", err)
+	}
+	out := buf.String()
+
+	if !strings.Contains(out, `class="cov-hit" title="1"`) {
+		t.Errorf("output missing cov-hit span: %s", out)
+	}
+	if !strings.Contains(out, `class="cov-miss" title="0"`) {
+		t.Errorf("output missing cov-miss span: %s", out)
+	}
+	if opens, closes := spanCounts(out); opens != closes {
+		t.Errorf("unbalanced spans: %d opens vs %d closes in %q", opens, closes, out)
+	}
+}
+
+// A block ending at the very last byte of src has a boundary offset equal to len(src),
+// which the byte-by-byte loop in genHTML never reaches; its closing </span> has to be
+// flushed separately or the rendered HTML comes out with an unclosed span.
+func TestGenHTMLClosesSpanEndingAtEOF(t *testing.T) {
+	src := []byte("package p\n\nfunc F() { hit() }")
+	lastLine := "func F() { hit() }"
+	profile := &cover.Profile{Blocks: []cover.ProfileBlock{
+		{StartLine: 3, StartCol: 1, EndLine: 3, EndCol: len(lastLine) + 1, NumStmt: 1, Count: 1},
+	}}
+
+	var buf bytes.Buffer
+	if err := genHTML(&buf, src, findBoundaries(src, profile)); err != nil {
+		t.Fatalf("genHTML returned error: %s", err)
+	}
+	out := buf.String()
+
+	if opens, closes := spanCounts(out); opens != closes {
+		t.Fatalf("unbalanced spans: %d opens vs %d closes in %q", opens, closes, out)
+	}
+	if !strings.HasSuffix(out, "</span>") {
+		t.Errorf("output does not end with a closed span: %q", out)
+	}
+}
+
+func TestOffsetForLocatesLineAndColumn(t *testing.T) {
+	src := []byte("ab\ncd\nef")
+	if got := offsetFor(src, 2, 1); got != 3 {
+		t.Errorf("offsetFor(line 2, col 1) = %d, want 3", got)
+	}
+	if got := offsetFor(src, 3, 2); got != 7 {
+		t.Errorf("offsetFor(line 3, col 2) = %d, want 7", got)
+	}
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"math"
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func block(startLine, startCol, endLine, endCol, numStmt, count int) cover.ProfileBlock {
+	return cover.ProfileBlock{StartLine: startLine, StartCol: startCol, EndLine: endLine, EndCol: endCol, NumStmt: numStmt, Count: count}
+}
+
+func TestMergeProfilesSumsCountsForCountMode(t *testing.T) {
+	a := []*cover.Profile{{FileName: "pkg/a.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 2, 1)}}}
+	b := []*cover.Profile{{FileName: "pkg/a.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 2, 4)}}}
+
+	merged, err := mergeProfiles(a, b)
+	if err != nil {
+		t.Fatalf("mergeProfiles returned error: %s", err)
+	}
+	if len(merged) != 1 || len(merged[0].Blocks) != 1 {
+		t.Fatalf("expected a single merged block, got %+v", merged)
+	}
+	if got := merged[0].Blocks[0].Count; got != 5 {
+		t.Errorf("Count = %d, want 5", got)
+	}
+}
+
+func TestMergeProfilesOrsCountsForSetMode(t *testing.T) {
+	a := []*cover.Profile{{FileName: "pkg/a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 2, 0)}}}
+	b := []*cover.Profile{{FileName: "pkg/a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 2, 1)}}}
+
+	merged, err := mergeProfiles(a, b)
+	if err != nil {
+		t.Fatalf("mergeProfiles returned error: %s", err)
+	}
+	if got := merged[0].Blocks[0].Count; got != 1 {
+		t.Errorf("Count = %d, want 1", got)
+	}
+}
+
+func TestMergeProfilesRejectsModeMismatch(t *testing.T) {
+	a := []*cover.Profile{{FileName: "pkg/a.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 2, 1)}}}
+	b := []*cover.Profile{{FileName: "pkg/a.go", Mode: "set", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 2, 1)}}}
+
+	if _, err := mergeProfiles(a, b); err == nil {
+		t.Fatal("expected an error for mismatched modes, got nil")
+	}
+}
+
+func TestMergeProfilesRejectsOverlappingMismatchedBlocks(t *testing.T) {
+	a := []*cover.Profile{{FileName: "pkg/a.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 1, 5, 2, 3, 1)}}}
+	b := []*cover.Profile{{FileName: "pkg/a.go", Mode: "count", Blocks: []cover.ProfileBlock{block(3, 1, 7, 2, 3, 1)}}}
+
+	if _, err := mergeProfiles(a, b); err == nil {
+		t.Fatal("expected an error for overlapping, non-identical blocks, got nil")
+	}
+}
+
+func TestAddCountsClampsOnOverflow(t *testing.T) {
+	if got := addCounts(math.MaxInt32, math.MaxInt32); got != math.MaxInt32 {
+		t.Errorf("addCounts(MaxInt32, MaxInt32) = %d, want %d", got, math.MaxInt32)
+	}
+	if got := addCounts(2, 3); got != 5 {
+		t.Errorf("addCounts(2, 3) = %d, want 5", got)
+	}
+}
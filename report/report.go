@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"golang.org/x/tools/cover"
+	"math"
+	"path"
 	"sort"
 	"strings"
 )
@@ -13,32 +15,93 @@ type Summary struct {
 	Name                                       string
 	Blocks, Stmts, MissingBlocks, MissingStmts int
 	BlockCoverage, StmtCoverage                float64
+	// Status is only set on a Report produced by Diff: "added", "removed" or ""
+	// for a name present in both the base and head reports.
+	Status string
 }
 
 // Report of the coverage results
 type Report struct {
-	Total Summary // Global coverage
-	Files []Summary // Coverage by file
+	Total      Summary      // Global coverage
+	Files      []Summary    // Coverage by file
+	Packages   []Summary    // Coverage by package (directory)
+	Subsystems []Summary    // Coverage by user-defined subsystem, see Options.Subsystems
+	Details    []FileDetail // Raw blocks behind each entry in Files, for formats that render source
+	Warnings   []string     // Non-fatal issues found while parsing/validating the coverprofiles
 }
 
-// Generates a coverage report given the coverage profile file, and the following configurations:
+// The raw coverage blocks for a single file, kept around (rather than discarded once
+// accumulated into a Summary) for report formats that need line-level detail, such as
+// Cobertura XML or an annotated HTML source view.
+type FileDetail struct {
+	Name   string // matches the corresponding Summary.Name in Report.Files
+	Mode   string
+	Blocks []cover.ProfileBlock
+}
+
+// Options carries groupings for the mid-level rollups in a Report, on top of the
+// per-file and Total coverage that GenerateReport always computes.
+type Options struct {
+	// Subsystems maps a subsystem name to a list of path-prefix globs: a glob ending in
+	// "/*" matches the directory it names and everything under it (so "m/*" matches both
+	// "m/x.go" and "m/sub/x.go"), while any other glob is matched with path.Match, whose
+	// "*" does not cross a "/". Any file whose (root-trimmed) path matches one of a
+	// subsystem's globs is rolled up into that subsystem's Summary, in addition to its
+	// own file and package.
+	Subsystems map[string][]string
+}
+
+// Generates a coverage report given one or more coverage profile files, merging them
+// gocovmerge-style when more than one is given, and the following configurations:
 // exclusions: packages to be excluded (if a package is excluded, all its subpackages are excluded as well)
 // sortBy: the order in which the files will be sorted in the report (see sortResults)
 // order: the direction of the the sorting
-func GenerateReport(coverprofile string, root string, exclusions []string, sortBy, order string) (Report, error) {
-	profiles, err := cover.ParseProfiles(coverprofile)
+func GenerateReport(coverprofiles []string, root string, exclusions []string, sortBy, order string, options Options, profileOptions ProfileOptions) (Report, error) {
+	profileSets := make([][]*cover.Profile, 0, len(coverprofiles))
+	var issues multiError
+	var warnings []string
+	for _, coverprofile := range coverprofiles {
+		profiles, fileWarnings, fileIssues := parseCoverprofile(coverprofile, profileOptions)
+		warnings = append(warnings, fileWarnings...)
+		issues = append(issues, fileIssues...)
+		if len(fileIssues) == 0 {
+			profileSets = append(profileSets, profiles)
+		}
+	}
+	if len(issues) > 0 {
+		return Report{}, issues
+	}
+	if profileOptions.Strict && len(warnings) > 0 {
+		return Report{}, asIssues(warnings)
+	}
+	merged, err := mergeProfiles(profileSets...)
 	if err != nil {
-		return Report{}, fmt.Errorf("Invalid coverprofile: '%s'", err)
+		return Report{}, err
 	}
+	r, err := GenerateReportFromProfiles(merged, root, exclusions, sortBy, order, options)
+	if err != nil {
+		return Report{}, err
+	}
+	r.Warnings = warnings
+	return r, nil
+}
+
+// Generates a coverage report from already-parsed profiles, e.g. ones merged by
+// mergeProfiles or read by a caller that needs the raw profiles for another purpose.
+// Unlike GenerateReport, it performs no negative-count validation: a block with a
+// negative Count is accumulated as an uncovered block.
+// root, exclusions, sortBy and order behave as in GenerateReport.
+func GenerateReportFromProfiles(profiles []*cover.Profile, root string, exclusions []string, sortBy, order string, options Options) (Report, error) {
 	total := &accumulator{name: "Total"}
 	files := make(map[string]*accumulator)
+	packages := make(map[string]*accumulator)
+	details := make(map[string]*FileDetail)
+	subsystems := make(map[string]*accumulator, len(options.Subsystems))
+	for name := range options.Subsystems {
+		subsystems[name] = &accumulator{name: name}
+	}
 	for _, profile := range profiles {
-		var fileName string
-		if root == "" {
-			fileName = profile.FileName
-		} else {
-			fileName = strings.Replace(profile.FileName, root+"/", "", -1)
-		}
+		fileName := trimRoot(profile.FileName, root)
 		skip := false
 		for _, exclusion := range exclusions {
 			if strings.HasPrefix(fileName, exclusion) {
@@ -53,26 +116,218 @@ func GenerateReport(coverprofile string, root string, exclusions []string, sortB
 			fileCover = &accumulator{name: fileName}
 			files[fileName] = fileCover
 		}
+		packageName := path.Dir(fileName)
+		packageCover, ok := packages[packageName]
+		if !ok {
+			packageCover = &accumulator{name: packageName}
+			packages[packageName] = packageCover
+		}
+		detail, ok := details[fileName]
+		if !ok {
+			detail = &FileDetail{Name: fileName, Mode: profile.Mode}
+			details[fileName] = detail
+		}
+		detail.Blocks = append(detail.Blocks, profile.Blocks...)
+		matchingSubsystems := matchSubsystems(fileName, options.Subsystems)
 		for _, block := range profile.Blocks {
 			total.add(block)
 			fileCover.add(block)
+			packageCover.add(block)
+			for _, name := range matchingSubsystems {
+				subsystems[name].add(block)
+			}
 		}
 	}
-	return makeReport(total, files, sortBy, order)
+	return makeReport(total, files, packages, subsystems, details, sortBy, order)
 }
 
-// Creates a Report struct from the coverage sumarization results
-func makeReport(total *accumulator, files map[string]*accumulator, sortBy, order string) (Report, error) {
-	fileReports := make([]Summary, 0, len(files))
-	for _, fileCover := range files {
-		fileReports = append(fileReports, fileCover.results())
+// Removes every occurrence of root+"/" from fileName (not only a leading one), the way GenerateReport and WriteHTML
+// turn a coverprofile's absolute/import-path file names into ones relative to root.
+func trimRoot(fileName, root string) string {
+	if root == "" {
+		return fileName
+	}
+	return strings.Replace(fileName, root+"/", "", -1)
+}
+
+// Returns the names of the subsystems whose globs match fileName.
+func matchSubsystems(fileName string, subsystems map[string][]string) []string {
+	var matches []string
+	for name, globs := range subsystems {
+		for _, glob := range globs {
+			if matchSubsystemGlob(glob, fileName) {
+				matches = append(matches, name)
+				break
+			}
+		}
 	}
+	return matches
+}
+
+// Reports whether fileName matches glob, per the path-prefix semantics documented on
+// Options.Subsystems: a glob ending in "/*" is a directory prefix, matching that
+// directory itself and everything under it, regardless of how many "/"s follow. Any
+// other glob falls back to path.Match's single-path-segment semantics.
+func matchSubsystemGlob(glob, fileName string) bool {
+	if prefix := strings.TrimSuffix(glob, "/*"); prefix != glob {
+		return fileName == prefix || strings.HasPrefix(fileName, prefix+"/")
+	}
+	ok, err := path.Match(glob, fileName)
+	return err == nil && ok
+}
+
+// Merges multiple sets of coverage profiles into a single set, the way gocovmerge does:
+// profiles are grouped by FileName, and blocks with identical (StartLine, StartCol,
+// EndLine, EndCol, NumStmt) are combined by summing (or, for mode "set", OR-ing) their
+// counts. Profiles for the same file must share the same Mode. Blocks that overlap
+// without matching exactly are rejected, since there would be no sound way to combine them.
+func mergeProfiles(profileSets ...[]*cover.Profile) ([]*cover.Profile, error) {
+	byFile := make(map[string]*cover.Profile)
+	var fileNames []string
+	for _, profiles := range profileSets {
+		for _, profile := range profiles {
+			existing, ok := byFile[profile.FileName]
+			if !ok {
+				merged := &cover.Profile{
+					FileName: profile.FileName,
+					Mode:     profile.Mode,
+					Blocks:   append([]cover.ProfileBlock{}, profile.Blocks...),
+				}
+				byFile[profile.FileName] = merged
+				fileNames = append(fileNames, profile.FileName)
+				continue
+			}
+			if existing.Mode != profile.Mode {
+				return nil, fmt.Errorf("cannot merge coverprofiles: mode mismatch for '%s': '%s' != '%s'", profile.FileName, existing.Mode, profile.Mode)
+			}
+			existing.Blocks = append(existing.Blocks, profile.Blocks...)
+		}
+	}
+	sort.Strings(fileNames)
+	merged := make([]*cover.Profile, 0, len(fileNames))
+	for _, fileName := range fileNames {
+		profile := byFile[fileName]
+		if err := mergeBlocks(profile); err != nil {
+			return nil, err
+		}
+		merged = append(merged, profile)
+	}
+	return merged, nil
+}
+
+// Sorts a profile's blocks and collapses ones that share the same position and NumStmt,
+// combining their counts. Returns an error if two blocks overlap without matching exactly.
+func mergeBlocks(profile *cover.Profile) error {
+	blocks := profile.Blocks
+	sort.Slice(blocks, func(i, j int) bool {
+		return blockLess(blocks[i], blocks[j])
+	})
+	result := blocks[:0]
+	for _, block := range blocks {
+		if len(result) > 0 {
+			last := &result[len(result)-1]
+			if samePosition(*last, block) {
+				if last.NumStmt != block.NumStmt {
+					return fmt.Errorf("inconsistent NumStmt for block at %s:%d.%d,%d.%d: %d != %d",
+						profile.FileName, last.StartLine, last.StartCol, last.EndLine, last.EndCol, last.NumStmt, block.NumStmt)
+				}
+				if profile.Mode == "set" {
+					if block.Count > 0 {
+						last.Count = 1
+					}
+				} else {
+					last.Count = addCounts(last.Count, block.Count)
+				}
+				continue
+			}
+			if overlaps(*last, block) {
+				return fmt.Errorf("overlapping but mismatched blocks in %s at %d.%d,%d.%d and %d.%d,%d.%d",
+					profile.FileName, last.StartLine, last.StartCol, last.EndLine, last.EndCol,
+					block.StartLine, block.StartCol, block.EndLine, block.EndCol)
+			}
+		}
+		result = append(result, block)
+	}
+	profile.Blocks = result
+	return nil
+}
+
+// Orders blocks by (StartLine, StartCol, EndLine, EndCol, NumStmt), as gocovmerge does
+// before merging, so identical blocks end up adjacent.
+func blockLess(a, b cover.ProfileBlock) bool {
+	if a.StartLine != b.StartLine {
+		return a.StartLine < b.StartLine
+	}
+	if a.StartCol != b.StartCol {
+		return a.StartCol < b.StartCol
+	}
+	if a.EndLine != b.EndLine {
+		return a.EndLine < b.EndLine
+	}
+	if a.EndCol != b.EndCol {
+		return a.EndCol < b.EndCol
+	}
+	return a.NumStmt < b.NumStmt
+}
+
+func samePosition(a, b cover.ProfileBlock) bool {
+	return a.StartLine == b.StartLine && a.StartCol == b.StartCol &&
+		a.EndLine == b.EndLine && a.EndCol == b.EndCol
+}
+
+// Reports whether two (already sorted) blocks overlap in the source they cover.
+func overlaps(a, b cover.ProfileBlock) bool {
+	aEnd := a.EndLine*1000000 + a.EndCol
+	bStart := b.StartLine*1000000 + b.StartCol
+	return bStart < aEnd
+}
+
+// Sums two coverage counts, clamping to math.MaxInt32 instead of overflowing, matching
+// the int32 counter width coverage instrumentation actually uses.
+func addCounts(a, b int) int {
+	sum := a + b
+	if sum < a || sum < b || sum > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return sum
+}
+
+// Creates a Report struct from the coverage sumarization results
+func makeReport(total *accumulator, files, packages, subsystems map[string]*accumulator, details map[string]*FileDetail, sortBy, order string) (Report, error) {
+	fileReports := summarize(files)
 	if err := sortResults(fileReports, sortBy, order); err != nil {
 		return Report{}, err
 	}
+	packageReports := summarize(packages)
+	if err := sortResults(packageReports, sortBy, order); err != nil {
+		return Report{}, err
+	}
+	subsystemReports := summarize(subsystems)
+	if err := sortResults(subsystemReports, sortBy, order); err != nil {
+		return Report{}, err
+	}
+	fileDetails := make([]FileDetail, 0, len(details))
+	for _, detail := range details {
+		fileDetails = append(fileDetails, *detail)
+	}
+	sort.Slice(fileDetails, func(i, j int) bool {
+		return fileDetails[i].Name < fileDetails[j].Name
+	})
 	return Report{
-		Total: total.results(),
-		Files: fileReports}, nil
+		Total:      total.results(),
+		Files:      fileReports,
+		Packages:   packageReports,
+		Subsystems: subsystemReports,
+		Details:    fileDetails}, nil
+}
+
+// Turns a map of accumulators into a slice of their Summary results
+func summarize(accumulators map[string]*accumulator) []Summary {
+	summaries := make([]Summary, 0, len(accumulators))
+	for _, a := range accumulators {
+		summaries = append(summaries, a.results())
+	}
+	return summaries
 }
 
 // Accumulates the coverage of a file and returns a summary
@@ -98,8 +353,17 @@ func (a *accumulator) results() Summary {
 		Stmts:         a.stmts,
 		MissingBlocks: a.blocks - a.coveredBlocks,
 		MissingStmts:  a.stmts - a.coveredStmts,
-		BlockCoverage: float64(a.coveredBlocks) / float64(a.blocks) * 100,
-		StmtCoverage:  float64(a.coveredStmts) / float64(a.stmts) * 100}
+		BlockCoverage: percentage(a.coveredBlocks, a.blocks),
+		StmtCoverage:  percentage(a.coveredStmts, a.stmts)}
+}
+
+// percentage returns covered/total*100, or 0 (rather than NaN) when total is 0, which
+// happens for an empty file or a subsystem whose globs matched no files.
+func percentage(covered, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(covered) / float64(total) * 100
 }
 
 // Sorts the individual coverage reports by a given column
@@ -127,7 +391,7 @@ func sortResults(reports []Summary, mode string, order string) error {
 		}
 	case "stmt":
 		less = func(i, j int) bool {
-			return reports[j].StmtCoverage < reports[j].StmtCoverage
+			return reports[i].StmtCoverage < reports[j].StmtCoverage
 		}
 	case "missing-blocks":
 		less = func(i, j int) bool {
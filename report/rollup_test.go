@@ -0,0 +1,63 @@
+package report
+
+import (
+	"testing"
+
+	"golang.org/x/tools/cover"
+)
+
+func rollupProfiles() []*cover.Profile {
+	return []*cover.Profile{
+		{FileName: "pkg/a.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 1, 1)}},
+		{FileName: "pkg/b.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 1, 0)}},
+		{FileName: "pkg/sub/c.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 1, 1)}},
+		{FileName: "other/d.go", Mode: "count", Blocks: []cover.ProfileBlock{block(1, 1, 3, 2, 1, 0)}},
+	}
+}
+
+func summaryByName(summaries []Summary, name string) (Summary, bool) {
+	for _, s := range summaries {
+		if s.Name == name {
+			return s, true
+		}
+	}
+	return Summary{}, false
+}
+
+func TestGenerateReportFromProfilesRollsUpByPackage(t *testing.T) {
+	r, err := GenerateReportFromProfiles(rollupProfiles(), "", nil, "filename", "asc", Options{})
+	if err != nil {
+		t.Fatalf("GenerateReportFromProfiles returned error: %s", err)
+	}
+
+	pkg, ok := summaryByName(r.Packages, "pkg")
+	if !ok {
+		t.Fatalf("no Summary for package 'pkg' in %+v", r.Packages)
+	}
+	if pkg.Stmts != 2 || pkg.MissingStmts != 1 {
+		t.Errorf("pkg Stmts/MissingStmts = %d/%d, want 2/1", pkg.Stmts, pkg.MissingStmts)
+	}
+
+	sub, ok := summaryByName(r.Packages, "pkg/sub")
+	if !ok || sub.MissingStmts != 0 {
+		t.Errorf("pkg/sub Summary = %+v, ok=%v, want MissingStmts 0", sub, ok)
+	}
+}
+
+func TestGenerateReportFromProfilesRollsUpBySubsystemPrefix(t *testing.T) {
+	options := Options{Subsystems: map[string][]string{"pkg-all": {"pkg/*"}}}
+
+	r, err := GenerateReportFromProfiles(rollupProfiles(), "", nil, "filename", "asc", options)
+	if err != nil {
+		t.Fatalf("GenerateReportFromProfiles returned error: %s", err)
+	}
+
+	subsystem, ok := summaryByName(r.Subsystems, "pkg-all")
+	if !ok {
+		t.Fatalf("no Summary for subsystem 'pkg-all' in %+v", r.Subsystems)
+	}
+	// "pkg/*" must match pkg/a.go, pkg/b.go AND the nested pkg/sub/c.go.
+	if subsystem.Stmts != 3 || subsystem.MissingStmts != 1 {
+		t.Errorf("pkg-all Stmts/MissingStmts = %d/%d, want 3/1", subsystem.Stmts, subsystem.MissingStmts)
+	}
+}
@@ -0,0 +1,30 @@
+package report
+
+import "testing"
+
+func TestSortResultsByStmtCoverage(t *testing.T) {
+	reports := []Summary{
+		{Name: "zero.go", StmtCoverage: 0},
+		{Name: "full.go", StmtCoverage: 100},
+		{Name: "half.go", StmtCoverage: 50},
+	}
+
+	if err := sortResults(reports, "stmt", "desc"); err != nil {
+		t.Fatalf("sortResults returned error: %s", err)
+	}
+
+	want := []string{"full.go", "half.go", "zero.go"}
+	for i, name := range want {
+		if reports[i].Name != name {
+			t.Fatalf("sorted order = %v, want %v", namesOf(reports), want)
+		}
+	}
+}
+
+func namesOf(reports []Summary) []string {
+	names := make([]string, len(reports))
+	for i, r := range reports {
+		names[i] = r.Name
+	}
+	return names
+}
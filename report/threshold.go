@@ -0,0 +1,151 @@
+package report
+
+import (
+	"fmt"
+	"path"
+	"sort"
+)
+
+// Minimum coverage a Summary must reach; a zero value for either field means that
+// metric isn't checked.
+type Minimum struct {
+	StmtCoverage  float64
+	BlockCoverage float64
+}
+
+// Thresholds to enforce on a Report. Files and Packages map a glob pattern (as
+// understood by path.Match) to the Minimum that any matching Summary must reach;
+// a name can match more than one pattern, in which case it must satisfy all of them.
+type Thresholds struct {
+	Total    Minimum
+	Files    map[string]Minimum
+	Packages map[string]Minimum
+}
+
+// A single threshold that a Report failed to meet.
+type Violation struct {
+	Scope     string // "total", "file" or "package"
+	Name      string
+	Metric    string // "StmtCoverage" or "BlockCoverage"
+	Threshold float64
+	Actual    float64
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s '%s': %s %.2f%% is below required minimum %.2f%%", v.Scope, v.Name, v.Metric, v.Actual, v.Threshold)
+}
+
+// Evaluates a Report against a set of Thresholds, returning every Violation found.
+// A nil/empty result means the report satisfies all the thresholds.
+func Evaluate(r Report, thresholds Thresholds) []Violation {
+	var violations []Violation
+	violations = append(violations, checkMinimum("total", r.Total, thresholds.Total)...)
+	violations = append(violations, checkGlobs("file", r.Files, thresholds.Files)...)
+	violations = append(violations, checkGlobs("package", r.Packages, thresholds.Packages)...)
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Name != violations[j].Name {
+			return violations[i].Name < violations[j].Name
+		}
+		return violations[i].Metric < violations[j].Metric
+	})
+	return violations
+}
+
+// Checks every Summary whose Name matches one of thresholds' glob patterns.
+func checkGlobs(scope string, summaries []Summary, thresholds map[string]Minimum) []Violation {
+	var violations []Violation
+	for _, summary := range summaries {
+		for glob, min := range thresholds {
+			if ok, err := path.Match(glob, summary.Name); err != nil || !ok {
+				continue
+			}
+			violations = append(violations, checkMinimum(scope, summary, min)...)
+		}
+	}
+	return violations
+}
+
+func checkMinimum(scope string, summary Summary, min Minimum) []Violation {
+	var violations []Violation
+	if min.StmtCoverage > 0 && summary.StmtCoverage < min.StmtCoverage {
+		violations = append(violations, Violation{Scope: scope, Name: summary.Name, Metric: "StmtCoverage", Threshold: min.StmtCoverage, Actual: summary.StmtCoverage})
+	}
+	if min.BlockCoverage > 0 && summary.BlockCoverage < min.BlockCoverage {
+		violations = append(violations, Violation{Scope: scope, Name: summary.Name, Metric: "BlockCoverage", Threshold: min.BlockCoverage, Actual: summary.BlockCoverage})
+	}
+	return violations
+}
+
+// FailUnder checks the Total statement coverage of r against min, the way a
+// --fail-under CLI flag would, wired straight through GenerateReport's output.
+func FailUnder(r Report, min float64) error {
+	if r.Total.StmtCoverage < min {
+		return fmt.Errorf("total statement coverage %.2f%% is below required minimum %.2f%%", r.Total.StmtCoverage, min)
+	}
+	return nil
+}
+
+// Diff produces a Report whose Summaries hold the coverage delta between base and
+// head (head - base): files/packages/subsystems only present in head are marked
+// "added", ones only present in base are marked "removed", see Summary.Status.
+func Diff(base, head Report) Report {
+	return Report{
+		Total:      diffSummary(base.Total, head.Total, ""),
+		Files:      diffSummaries(base.Files, head.Files),
+		Packages:   diffSummaries(base.Packages, head.Packages),
+		Subsystems: diffSummaries(base.Subsystems, head.Subsystems),
+	}
+}
+
+// Diffs two lists of Summaries keyed by Name.
+func diffSummaries(base, head []Summary) []Summary {
+	baseByName := make(map[string]Summary, len(base))
+	for _, summary := range base {
+		baseByName[summary.Name] = summary
+	}
+	headByName := make(map[string]Summary, len(head))
+	for _, summary := range head {
+		headByName[summary.Name] = summary
+	}
+	names := make(map[string]bool, len(base)+len(head))
+	for name := range baseByName {
+		names[name] = true
+	}
+	for name := range headByName {
+		names[name] = true
+	}
+	diffs := make([]Summary, 0, len(names))
+	for name := range names {
+		baseSummary, inBase := baseByName[name]
+		headSummary, inHead := headByName[name]
+		status := ""
+		switch {
+		case !inBase:
+			status = "added"
+		case !inHead:
+			status = "removed"
+		}
+		diffs = append(diffs, diffSummary(baseSummary, headSummary, status))
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+func diffSummary(base, head Summary, status string) Summary {
+	name := head.Name
+	if name == "" {
+		name = base.Name
+	}
+	return Summary{
+		Name:          name,
+		Status:        status,
+		Blocks:        head.Blocks - base.Blocks,
+		Stmts:         head.Stmts - base.Stmts,
+		MissingBlocks: head.MissingBlocks - base.MissingBlocks,
+		MissingStmts:  head.MissingStmts - base.MissingStmts,
+		BlockCoverage: head.BlockCoverage - base.BlockCoverage,
+		StmtCoverage:  head.StmtCoverage - base.StmtCoverage,
+	}
+}
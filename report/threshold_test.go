@@ -0,0 +1,66 @@
+package report
+
+import "testing"
+
+func TestEvaluateFlagsBelowMinimum(t *testing.T) {
+	r := Report{
+		Total: Summary{Name: "Total", StmtCoverage: 92, BlockCoverage: 80},
+		Files: []Summary{
+			{Name: "pkg/good.go", StmtCoverage: 95},
+			{Name: "pkg/bad.go", StmtCoverage: 40},
+		},
+	}
+	thresholds := Thresholds{
+		Total: Minimum{StmtCoverage: 90},
+		Files: map[string]Minimum{"pkg/*.go": {StmtCoverage: 80}},
+	}
+
+	violations := Evaluate(r, thresholds)
+	if len(violations) != 1 {
+		t.Fatalf("expected 1 violation, got %d: %+v", len(violations), violations)
+	}
+	if violations[0].Name != "pkg/bad.go" || violations[0].Metric != "StmtCoverage" {
+		t.Errorf("unexpected violation: %+v", violations[0])
+	}
+}
+
+func TestEvaluateNoViolationsWhenThresholdsMet(t *testing.T) {
+	r := Report{Total: Summary{StmtCoverage: 95, BlockCoverage: 90}}
+	thresholds := Thresholds{Total: Minimum{StmtCoverage: 90, BlockCoverage: 80}}
+
+	if violations := Evaluate(r, thresholds); len(violations) != 0 {
+		t.Errorf("expected no violations, got %+v", violations)
+	}
+}
+
+func TestDiffMarksAddedAndRemovedFiles(t *testing.T) {
+	base := Report{Files: []Summary{{Name: "pkg/a.go", StmtCoverage: 50}, {Name: "pkg/removed.go", StmtCoverage: 100}}}
+	head := Report{Files: []Summary{{Name: "pkg/a.go", StmtCoverage: 70}, {Name: "pkg/added.go", StmtCoverage: 0}}}
+
+	diff := Diff(base, head)
+
+	byName := make(map[string]Summary, len(diff.Files))
+	for _, f := range diff.Files {
+		byName[f.Name] = f
+	}
+
+	if got := byName["pkg/a.go"].StmtCoverage; got != 20 {
+		t.Errorf("pkg/a.go StmtCoverage delta = %v, want 20", got)
+	}
+	if status := byName["pkg/added.go"].Status; status != "added" {
+		t.Errorf("pkg/added.go Status = %q, want \"added\"", status)
+	}
+	if status := byName["pkg/removed.go"].Status; status != "removed" {
+		t.Errorf("pkg/removed.go Status = %q, want \"removed\"", status)
+	}
+}
+
+func TestFailUnder(t *testing.T) {
+	r := Report{Total: Summary{StmtCoverage: 79.9}}
+	if err := FailUnder(r, 80); err == nil {
+		t.Error("expected an error when total coverage is below min")
+	}
+	if err := FailUnder(r, 79); err != nil {
+		t.Errorf("expected no error when total coverage is above min, got %s", err)
+	}
+}
@@ -0,0 +1,116 @@
+package report
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/cover"
+)
+
+// ProfileOptions controls how GenerateReport reacts to problems found in a coverprofile
+// beyond what cover.ParseProfiles itself rejects.
+type ProfileOptions struct {
+	// AllowNegativeCounts tolerates negative block counts (which can show up when an
+	// instrumented binary's counters overflow) by clamping them to zero and recording a
+	// warning, instead of failing the whole report. Defaults to false.
+	AllowNegativeCounts bool
+	// Strict escalates any warning (including a clamped negative count) into a hard
+	// error, so CI can treat "coverage data needed cleanup" as a failure.
+	Strict bool
+}
+
+// parseCoverprofile reads and parses a single coverprofile file, tolerating negative
+// counts per options instead of relying on cover.ParseProfiles: that function rejects
+// them outright (cover.Profile's own line scanner treats a negative Count as a hard
+// parse error), so negative counts have to be sanitized in the raw text before the
+// line ever reaches it. Returns any non-fatal warnings, and, independently, every fatal
+// issue found (collected across the whole file rather than stopping at the first one).
+func parseCoverprofile(coverprofilePath string, options ProfileOptions) (profiles []*cover.Profile, warnings []string, issues []error) {
+	f, err := os.Open(coverprofilePath)
+	if err != nil {
+		return nil, nil, []error{err}
+	}
+	defer f.Close()
+
+	var sanitized bytes.Buffer
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if lineNo == 1 {
+			// "mode: foo" line, passed through verbatim.
+			sanitized.WriteString(line)
+			sanitized.WriteByte('\n')
+			continue
+		}
+		fixed, warning, issue := sanitizeLine(coverprofilePath, lineNo, line, options)
+		if issue != nil {
+			issues = append(issues, issue)
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+		sanitized.WriteString(fixed)
+		sanitized.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		issues = append(issues, fmt.Errorf("%s: %s", coverprofilePath, err))
+	}
+	if len(issues) > 0 {
+		return nil, warnings, issues
+	}
+
+	profiles, err = cover.ParseProfilesFromReader(&sanitized)
+	if err != nil {
+		return nil, warnings, []error{fmt.Errorf("Invalid coverprofile '%s': %s", coverprofilePath, err)}
+	}
+	return profiles, warnings, nil
+}
+
+// sanitizeLine inspects a single coverprofile data line ("file.go:1.2,3.4 5 6") and,
+// if its count is negative, either clamps it to zero (recording a warning) or records
+// a fatal issue, depending on options.AllowNegativeCounts. Returns the line to feed to
+// cover.ParseProfilesFromReader, which is `line` unchanged unless it was clamped.
+func sanitizeLine(coverprofilePath string, lineNo int, line string, options ProfileOptions) (fixed string, warning string, issue error) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return line, "", nil // let cover.ParseProfilesFromReader produce the real error
+	}
+	count, err := strconv.Atoi(fields[2])
+	if err != nil || count >= 0 {
+		return line, "", nil
+	}
+	location := fmt.Sprintf("%s:%d: %s", coverprofilePath, lineNo, fields[0])
+	if !options.AllowNegativeCounts {
+		return line, "", fmt.Errorf("%s: negative count %d", location, count)
+	}
+	fields[2] = "0"
+	return strings.Join(fields, " "), fmt.Sprintf("%s: negative count %d clamped to 0", location, count), nil
+}
+
+// Turns plain warning strings into errors, so they can be reported through the same
+// multiError path as fatal issues once ProfileOptions.Strict upgrades them.
+func asIssues(warnings []string) multiError {
+	issues := make(multiError, len(warnings))
+	for i, warning := range warnings {
+		issues[i] = fmt.Errorf("%s", warning)
+	}
+	return issues
+}
+
+// multiError collects every issue found while parsing/validating a set of
+// coverprofiles, instead of surfacing only the first one.
+type multiError []error
+
+func (m multiError) Error() string {
+	messages := make([]string, len(m))
+	for i, err := range m {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d coverprofile issue(s):\n%s", len(m), strings.Join(messages, "\n"))
+}
@@ -0,0 +1,63 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCoverprofile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cover.out")
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseCoverprofileRejectsNegativeCountByDefault(t *testing.T) {
+	path := writeCoverprofile(t, "mode: count\npkg/a.go:1.1,3.2 2 -3\n")
+
+	profiles, warnings, issues := parseCoverprofile(path, ProfileOptions{})
+
+	if profiles != nil {
+		t.Errorf("expected no profiles, got %+v", profiles)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %+v", warnings)
+	}
+	if len(issues) != 1 || !strings.Contains(issues[0].Error(), "negative count -3") {
+		t.Fatalf("expected a single negative-count issue, got %+v", issues)
+	}
+}
+
+func TestParseCoverprofileClampsAndWarnsWhenAllowed(t *testing.T) {
+	path := writeCoverprofile(t, "mode: count\npkg/a.go:1.1,3.2 2 -3\n")
+
+	profiles, warnings, issues := parseCoverprofile(path, ProfileOptions{AllowNegativeCounts: true})
+
+	if len(issues) != 0 {
+		t.Fatalf("expected no issues, got %+v", issues)
+	}
+	if len(warnings) != 1 || !strings.Contains(warnings[0], "negative count -3 clamped to 0") {
+		t.Fatalf("expected a single clamp warning, got %+v", warnings)
+	}
+	if len(profiles) != 1 || len(profiles[0].Blocks) != 1 || profiles[0].Blocks[0].Count != 0 {
+		t.Fatalf("expected the clamped block to carry Count 0, got %+v", profiles)
+	}
+}
+
+func TestParseCoverprofileStrictEscalatesWarningToError(t *testing.T) {
+	path := writeCoverprofile(t, "mode: count\npkg/a.go:1.1,3.2 2 -3\n")
+
+	_, warnings, issues := parseCoverprofile(path, ProfileOptions{AllowNegativeCounts: true, Strict: true})
+	if len(issues) != 0 {
+		t.Fatalf("parseCoverprofile itself should only warn, got issues %+v", issues)
+	}
+
+	err := asIssues(warnings)
+	if len(err) != 1 || !strings.Contains(err.Error(), "negative count -3 clamped to 0") {
+		t.Fatalf("expected Strict's asIssues to escalate the clamp warning, got %s", err.Error())
+	}
+}